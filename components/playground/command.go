@@ -14,67 +14,15 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"context"
 	"strconv"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/components/playground/instance"
+	"github.com/pingcap/tiup/pkg/playground/client"
 	"github.com/spf13/cobra"
 )
 
-// CommandType send to playground.
-type CommandType string
-
-// types of CommandType
-const (
-	ScaleInCommandType  CommandType = "scale-in"
-	ScaleOutCommandType CommandType = "scale-out"
-	DisplayCommandType  CommandType = "display"
-	RestartCommandType	CommandType = "handleRestart"
-	PartitionCommandType CommandType = "handlePartition"
-)
-
-// Command send to Playground.
-type Command struct {
-	CommandType CommandType
-	PID         int // Set when scale-in
-	ComponentID string
-	instance.Config
-}
-
-func buildCommands(tp CommandType, opt *bootOptions) (cmds []Command) {
-	commands := []struct {
-		comp string
-		instance.Config
-	}{
-		{"pd", opt.pd},
-		{"tikv", opt.tikv},
-		{"pump", opt.pump},
-		{"tiflash", opt.tiflash},
-		{"tidb", opt.tidb},
-		{"ticdc", opt.ticdc},
-		{"drainer", opt.drainer},
-	}
-
-	for _, cmd := range commands {
-		for i := 0; i < cmd.Num; i++ {
-			c := Command{
-				CommandType: tp,
-				ComponentID: cmd.comp,
-				Config:      cmd.Config,
-			}
-
-			cmds = append(cmds, c)
-		}
-	}
-	return
-}
-
 func newScaleOut() *cobra.Command {
 	var opt bootOptions
 	cmd := &cobra.Command{
@@ -156,153 +104,124 @@ func newDisplay() *cobra.Command {
 	return cmd
 }
 
-func scaleIn(pids []int) error {
-	port, err := targetTag()
-	if err != nil {
-		return errors.AddStack(err)
+func newRestart() *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "handleRestart instances",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return restart(args)
+		},
 	}
+	return cmd
+}
 
-	var cmds []Command
-	for _, pid := range pids {
-		c := Command{
-			CommandType: ScaleInCommandType,
-			PID:         pid,
-		}
-		cmds = append(cmds, c)
+// buildInstanceSpecs turns the scale-out flags into the typed specs the
+// playground API expects, skipping components that weren't asked for.
+func buildInstanceSpecs(opt *bootOptions) (specs []client.InstanceSpec) {
+	components := []struct {
+		comp string
+		instance.Config
+	}{
+		{"pd", opt.pd},
+		{"tikv", opt.tikv},
+		{"pump", opt.pump},
+		{"tiflash", opt.tiflash},
+		{"tidb", opt.tidb},
+		{"ticdc", opt.ticdc},
+		{"drainer", opt.drainer},
 	}
 
-	addr := "127.0.0.1:" + strconv.Itoa(port)
-	return sendCommandsAndPrintResult(cmds, addr)
+	for _, c := range components {
+		if c.Num == 0 {
+			continue
+		}
+		specs = append(specs, client.InstanceSpec{
+			Component:  c.comp,
+			Num:        c.Num,
+			Host:       c.Host,
+			ConfigPath: c.ConfigPath,
+			BinPath:    c.BinPath,
+		})
+	}
+	return
 }
 
 func scaleOut(args []string, opt *bootOptions) (num int, err error) {
-	port, err := targetTag()
+	pc, err := playgroundClient()
 	if err != nil {
 		return 0, errors.AddStack(err)
 	}
 
-	cmds := buildCommands(ScaleOutCommandType, opt)
-	if len(cmds) == 0 {
+	specs := buildInstanceSpecs(opt)
+	if len(specs) == 0 {
 		return 0, nil
 	}
 
-	addr := "127.0.0.1:" + strconv.Itoa(port)
-	return len(cmds), sendCommandsAndPrintResult(cmds, addr)
+	instances, err := pc.ScaleOut(context.Background(), specs)
+	if err != nil {
+		return 0, errors.AddStack(err)
+	}
+	return len(instances), nil
 }
 
-func display(args []string) error {
-	port, err := targetTag()
+func scaleIn(pids []int) error {
+	pc, err := playgroundClient()
 	if err != nil {
 		return errors.AddStack(err)
 	}
-	c := Command{
-		CommandType: DisplayCommandType,
-	}
-
-	addr := "127.0.0.1:" + strconv.Itoa(port)
-	return sendCommandsAndPrintResult([]Command{c}, addr)
-}
-
-func sendCommandsAndPrintResult(cmds []Command, addr string) error {
-	for _, cmd := range cmds {
-		rc, err := requestCommand(cmd, addr)
-		if err != nil {
-			return errors.AddStack(err)
-		}
 
-		_, err = io.Copy(os.Stdout, rc)
-		rc.Close()
-		if err != nil {
+	for _, pid := range pids {
+		if err := pc.ScaleIn(context.Background(), pid); err != nil {
 			return errors.AddStack(err)
 		}
 	}
-
 	return nil
 }
 
-func requestCommand(cmd Command, addr string) (r io.ReadCloser, err error) {
-	data, err := json.Marshal(&cmd)
+func restart(args []string) error {
+	pc, err := playgroundClient()
 	if err != nil {
-		return nil, errors.AddStack(err)
+		return errors.AddStack(err)
 	}
 
-	url := fmt.Sprintf("http://%s/command", addr)
-
-	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
-	if err != nil {
-		return nil, errors.AddStack(err)
+	for _, arg := range args {
+		pid, err := strconv.Atoi(arg)
+		if err != nil {
+			return errors.Errorf("invalid pid %q", arg)
+		}
+		if err := pc.Restart(context.Background(), pid); err != nil {
+			return errors.AddStack(err)
+		}
 	}
-
-	return resp.Body, nil
+	return nil
 }
 
-func newPartition() *cobra.Command {
-	cmd := &cobra.Command {
-		Use: "handlePartition a component instance",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return partition(args)
-		},
+func display(args []string) error {
+	pc, err := playgroundClient()
+	if err != nil {
+		return errors.AddStack(err)
 	}
-	return cmd
-}
 
-func partition(args []string) error {
-	port, err := targetTag()
+	instances, err := pc.Display(context.Background())
 	if err != nil {
-		return err
-	}
-	var cmds []Command
-	for _, arg := range args {
-		pid, _ := strconv.Atoi(arg)
-		c := Command {
-			CommandType: PartitionCommandType,
-			PID: 		pid,
-		}
-		cmds = append(cmds, c)
+		return errors.AddStack(err)
 	}
-	addr := "127.0.0.1" + strconv.Itoa(port)
-	return sendCommandsAndPrintResult(cmds, addr)
+
+	printInstancesTable(instances)
+	return nil
 }
 
-func newRestart() *cobra.Command {
-	cmd := &cobra.Command {
-		Use: "handleRestart instances",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return restart(args)
-		},
-	}
-	return cmd
+// playgroundAddr formats the loopback address a playground server listening
+// on port is reachable at.
+func playgroundAddr(port int) string {
+	return "127.0.0.1:" + strconv.Itoa(port)
 }
 
-func restart(args []string) error {
+// playgroundClient builds a client.Client bound to the playground identified
+// by the current session's target tag.
+func playgroundClient() (client.Client, error) {
 	port, err := targetTag()
 	if err != nil {
-		return err
-	}
-	var cmds []Command
-
-	for _, arg := range args {
-		pid, _ := strconv.Atoi(arg)
-		c := Command {
-			CommandType: RestartCommandType,
-			PID: 		pid,
-		}
-		cmds = append(cmds, c)
+		return nil, errors.AddStack(err)
 	}
-	addr := "127.0.0.1" + strconv.Itoa(port)
-	return sendCommandsAndPrintResult(cmds, addr)
+	return client.NewClient(playgroundAddr(port)), nil
 }
-//
-//func newUnPartition() *cobra.Command {
-//	cmd := &cobra.Command {
-//		Use: "remove partition on a component instance",
-//		RunE: func(cmd *cobra.Command, args []string) error {
-//			return unpartition(args)
-//		},
-//	}
-//	return cmd
-//}
-//
-//func unpartition(args []string) error {
-//
-//}
@@ -0,0 +1,24 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestPlaygroundAddr(t *testing.T) {
+	got := playgroundAddr(4261)
+	want := "127.0.0.1:4261"
+	if got != want {
+		t.Fatalf("playgroundAddr(4261) = %q, want %q", got, want)
+	}
+}
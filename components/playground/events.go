@@ -0,0 +1,82 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/playground/client"
+)
+
+// fullSnapshotInterval is how often a watcher receives a full snapshot even
+// if nothing changed, so a client that missed a diff can resync.
+const fullSnapshotInterval = 30 * time.Second
+
+// eventBus fans out instance-state events to every `watch` subscriber.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[chan client.Event]struct{}
+}
+
+func newEventBus() *eventBus {
+	b := &eventBus{subs: make(map[chan client.Event]struct{})}
+	return b
+}
+
+// subscribe registers a new watcher and returns its channel. The caller must
+// call unsubscribe once it stops reading.
+func (b *eventBus) subscribe() chan client.Event {
+	ch := make(chan client.Event, 8)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *eventBus) unsubscribe(ch chan client.Event) {
+	b.mu.Lock()
+	delete(b.subs, ch)
+	b.mu.Unlock()
+	close(ch)
+}
+
+func (b *eventBus) publish(ev client.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			// Slow watcher; drop the event rather than block scale/restart/fault handlers.
+		}
+	}
+}
+
+// runPeriodicSnapshots publishes a full snapshot of s on every tick, until
+// stop is closed. It is meant to be run in its own goroutine for the
+// lifetime of the server.
+func (b *eventBus) runPeriodicSnapshots(s *state, stop <-chan struct{}) {
+	ticker := time.NewTicker(fullSnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.publish(client.Event{Full: true, Instances: s.snapshot()})
+		case <-stop:
+			return
+		}
+	}
+}
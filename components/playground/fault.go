@@ -0,0 +1,150 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/playground/client"
+	"github.com/spf13/cobra"
+)
+
+// fault types accepted by the /instances/{pid}/faults API.
+const (
+	faultTypePartition   = "partition"
+	faultTypeUnpartition = "unpartition"
+	faultTypeDelay       = "delay"
+	faultTypeLoss        = "loss"
+)
+
+func newPartition() *cobra.Command {
+	var pid int
+	var target string
+	var duration time.Duration
+
+	cmd := &cobra.Command{
+		Use:     "partition",
+		Short:   "Cut network connectivity between an instance and a target",
+		Example: "tiup playground partition --pid 1234 --target 5678 --duration 30s",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pid == 0 || target == "" {
+				return cmd.Help()
+			}
+			return injectFault(pid, client.FaultSpec{Type: faultTypePartition, Target: target, Duration: client.Duration(duration)})
+		},
+	}
+
+	cmd.Flags().IntVar(&pid, "pid", 0, "pid of the instance to partition")
+	cmd.Flags().StringVar(&target, "target", "", "peer pid or component name to cut off from")
+	cmd.Flags().DurationVar(&duration, "duration", 0, "automatically heal the partition after this long, 0 means until `unpartition` is run")
+
+	return cmd
+}
+
+func newUnPartition() *cobra.Command {
+	var pid int
+	var target string
+
+	cmd := &cobra.Command{
+		Use:     "unpartition",
+		Short:   "Remove a previously injected partition between an instance and a target",
+		Example: "tiup playground unpartition --pid 1234 --target 5678",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pid == 0 || target == "" {
+				return cmd.Help()
+			}
+			return injectFault(pid, client.FaultSpec{Type: faultTypeUnpartition, Target: target})
+		},
+	}
+
+	cmd.Flags().IntVar(&pid, "pid", 0, "pid of the partitioned instance")
+	cmd.Flags().StringVar(&target, "target", "", "peer pid or component name to restore connectivity to")
+
+	return cmd
+}
+
+func newNetworkDelay() *cobra.Command {
+	var pid int
+	var target string
+	var duration time.Duration
+	var latency time.Duration
+	var jitter time.Duration
+
+	cmd := &cobra.Command{
+		Use:     "delay",
+		Short:   "Add latency to the network path between an instance and a target",
+		Example: "tiup playground delay --pid 1234 --target tikv --latency 50ms --jitter 10ms --duration 1m",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pid == 0 || latency == 0 {
+				return cmd.Help()
+			}
+			return injectFault(pid, client.FaultSpec{
+				Type:     faultTypeDelay,
+				Target:   target,
+				Duration: client.Duration(duration),
+				Latency:  client.Duration(latency),
+				Jitter:   client.Duration(jitter),
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&pid, "pid", 0, "pid of the instance to delay")
+	cmd.Flags().StringVar(&target, "target", "", "peer pid or component name to scope the delay to, empty means all traffic")
+	cmd.Flags().DurationVar(&duration, "duration", 0, "automatically remove the delay after this long, 0 means until removed")
+	cmd.Flags().DurationVar(&latency, "latency", 50*time.Millisecond, "extra latency to add")
+	cmd.Flags().DurationVar(&jitter, "jitter", 0, "latency jitter")
+
+	return cmd
+}
+
+func newPacketLoss() *cobra.Command {
+	var pid int
+	var target string
+	var duration time.Duration
+	var loss string
+
+	cmd := &cobra.Command{
+		Use:     "loss",
+		Short:   "Drop a percentage of packets between an instance and a target",
+		Example: "tiup playground loss --pid 1234 --target tikv --loss 5% --duration 1m",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pid == 0 || loss == "" {
+				return cmd.Help()
+			}
+			return injectFault(pid, client.FaultSpec{
+				Type:     faultTypeLoss,
+				Target:   target,
+				Duration: client.Duration(duration),
+				Loss:     loss,
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&pid, "pid", 0, "pid of the instance to drop packets on")
+	cmd.Flags().StringVar(&target, "target", "", "peer pid or component name to scope the loss to, empty means all traffic")
+	cmd.Flags().DurationVar(&duration, "duration", 0, "automatically remove the loss after this long, 0 means until removed")
+	cmd.Flags().StringVar(&loss, "loss", "", "percentage of packets to drop, e.g. 5%")
+
+	return cmd
+}
+
+func injectFault(pid int, fault client.FaultSpec) error {
+	pc, err := playgroundClient()
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	return pc.InjectFault(context.Background(), pid, fault)
+}
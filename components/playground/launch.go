@@ -0,0 +1,73 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground/instance"
+)
+
+// basePort is the first port handed out to spawned instances; the server
+// allocates one sequentially to each instance it starts.
+const basePort = 20000
+
+// processHandle wraps a running instance's OS process so state.go doesn't
+// need to know how it was launched.
+type processHandle struct {
+	proc *os.Process
+}
+
+func (h *processHandle) Pid() int {
+	return h.proc.Pid
+}
+
+// Alive reports whether the process is still running. Sending the null
+// signal checks liveness without affecting the process.
+func (h *processHandle) Alive() bool {
+	return h.proc.Signal(syscall.Signal(0)) == nil
+}
+
+func (h *processHandle) Kill() {
+	_ = h.proc.Kill()
+}
+
+// launchInstance starts the binary described by cfg and returns a handle to
+// the running process, so the caller can track its pid and later restart or
+// kill it.
+func launchInstance(cfg instance.Config, port int) (*processHandle, error) {
+	args := []string{"--port", strconv.Itoa(port)}
+	if cfg.ConfigPath != "" {
+		args = append(args, "--config", cfg.ConfigPath)
+	}
+	if cfg.Host != "" {
+		args = append(args, "--host", cfg.Host)
+	}
+
+	c := exec.Command(cfg.BinPath, args...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	if err := c.Start(); err != nil {
+		return nil, errors.AddStack(err)
+	}
+	// Reap the child when it exits so it doesn't linger as a zombie, which
+	// would still answer Alive's null signal.
+	go func() { _ = c.Wait() }()
+	return &processHandle{proc: c.Process}, nil
+}
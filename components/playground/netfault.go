@@ -0,0 +1,133 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+// tcDevice is the network device every playground instance listens on;
+// instances only ever talk to each other over loopback.
+const tcDevice = "lo"
+
+// applyPartition drops traffic between two loopback ports in both directions.
+func applyPartition(portA, portB int) error {
+	if err := runIPTables(partitionRuleArgs("-A", portA, portB)); err != nil {
+		return err
+	}
+	return runIPTables(partitionRuleArgs("-A", portB, portA))
+}
+
+// removePartition undoes a previous applyPartition between the same ports.
+func removePartition(portA, portB int) error {
+	if err := runIPTables(partitionRuleArgs("-D", portA, portB)); err != nil {
+		return err
+	}
+	return runIPTables(partitionRuleArgs("-D", portB, portA))
+}
+
+func partitionRuleArgs(action string, srcPort, dstPort int) []string {
+	return []string{action, "OUTPUT", "-p", "tcp", "--sport", strconv.Itoa(srcPort), "--dport", strconv.Itoa(dstPort), "-j", "DROP"}
+}
+
+// applyDelay adds latency (and optional jitter) to traffic destined for port.
+// If peerPorts is non-empty, the delay is scoped to traffic exchanged with
+// those peers only; otherwise it applies to all of port's traffic.
+func applyDelay(port int, peerPorts []int, latency, jitter time.Duration) error {
+	args := []string{"delay", latency.String()}
+	if jitter > 0 {
+		args = append(args, jitter.String())
+	}
+	return applyNetem(port, peerPorts, args)
+}
+
+// applyLoss drops loss percent of the packets destined for port, e.g. "5%".
+// peerPorts scopes the loss the same way applyDelay's does.
+func applyLoss(port int, peerPorts []int, loss string) error {
+	return applyNetem(port, peerPorts, []string{"loss", loss})
+}
+
+// applyNetem scopes a `tc qdisc ... netem` rule to port via a classful filter,
+// so only traffic to that instance is affected. With peerPorts given, two
+// filters per peer (one per direction) are added so only traffic to/from
+// that peer is shaped.
+func applyNetem(port int, peerPorts []int, netemArgs []string) error {
+	classID := classIDForPort(port)
+
+	// The shared prio qdisc may already exist from a previous fault on
+	// another instance; that's fine, every class just hangs off it.
+	_ = runTC("qdisc", "add", "dev", tcDevice, "root", "handle", "1:", "prio")
+
+	qdiscArgs := append([]string{"qdisc", "add", "dev", tcDevice, "parent", "1:" + classID, "handle", classID + "0:", "netem"}, netemArgs...)
+	if err := runTC(qdiscArgs...); err != nil {
+		return err
+	}
+
+	if len(peerPorts) == 0 {
+		return runTC("filter", "add", "dev", tcDevice, "protocol", "ip", "parent", "1:0", "prio", classID,
+			"u32", "match", "ip", "dport", strconv.Itoa(port), "0xffff", "flowid", "1:"+classID)
+	}
+
+	for _, peer := range peerPorts {
+		// Shape both directions of the conversation, like applyPartition does.
+		if err := runTC("filter", "add", "dev", tcDevice, "protocol", "ip", "parent", "1:0", "prio", classID,
+			"u32", "match", "ip", "dport", strconv.Itoa(port), "0xffff",
+			"match", "ip", "sport", strconv.Itoa(peer), "0xffff", "flowid", "1:"+classID); err != nil {
+			return err
+		}
+		if err := runTC("filter", "add", "dev", tcDevice, "protocol", "ip", "parent", "1:0", "prio", classID,
+			"u32", "match", "ip", "sport", strconv.Itoa(port), "0xffff",
+			"match", "ip", "dport", strconv.Itoa(peer), "0xffff", "flowid", "1:"+classID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeNetem tears down the delay/loss rule previously scoped to port.
+func removeNetem(port int) error {
+	classID := classIDForPort(port)
+	_ = runTC("filter", "del", "dev", tcDevice, "protocol", "ip", "parent", "1:0", "prio", classID)
+	return runTC("qdisc", "del", "dev", tcDevice, "parent", "1:"+classID, "handle", classID+"0:")
+}
+
+// classIDForPort maps a port to a small hex class id, the id space tc expects for handles.
+func classIDForPort(port int) string {
+	return fmt.Sprintf("%x", port%250+1)
+}
+
+func runIPTables(args []string) error {
+	cmd := exec.Command("iptables", args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Annotatef(err, "iptables %s", strings.Join(args, " "))
+	}
+	return nil
+}
+
+func runTC(args ...string) error {
+	cmd := exec.Command("tc", args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Annotatef(err, "tc %s", strings.Join(args, " "))
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/playground/client"
+)
+
+// Server implements the playground's `/api/v1/*` HTTP API described in
+// components/playground/api/openapi.yaml. The cobra commands in this package
+// (scale-out/scale-in/restart/display/...) are all clients of a Server over
+// pkg/playground/client.
+type Server struct {
+	state *state
+	stop  chan struct{}
+}
+
+// NewServer returns a Server with an empty instance registry.
+func NewServer() *Server {
+	s := &Server{state: newState(), stop: make(chan struct{})}
+	go s.state.bus.runPeriodicSnapshots(s.state, s.stop)
+	return s
+}
+
+// Handler returns the server's routes, for use with httptest or a custom
+// http.Server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/instances", s.handleInstancesCollection)
+	mux.HandleFunc("/api/v1/instances/", s.handleInstanceItem)
+	mux.HandleFunc("/api/v1/events", s.handleEvents)
+	return mux
+}
+
+// ListenAndServe starts the playground's HTTP API on addr, blocking until it exits.
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+// Close stops the server's background periodic-snapshot goroutine.
+func (s *Server) Close() {
+	close(s.stop)
+}
+
+func (s *Server) handleInstancesCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.state.snapshot())
+
+	case http.MethodPost:
+		var specs []client.InstanceSpec
+		if err := json.NewDecoder(r.Body).Decode(&specs); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		instances, err := s.state.scaleOut(specs)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, instances)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (s *Server) handleInstanceItem(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/instances/")
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+
+	pid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.Errorf("invalid pid %q", parts[0]))
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if err := s.state.scaleIn(pid); err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 2 && parts[1] == "restart" && r.Method == http.MethodPost:
+		if err := s.state.restart(pid); err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 2 && parts[1] == "faults" && r.Method == http.MethodPost:
+		var fault client.FaultSpec
+		if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := s.state.injectFault(pid, fault); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusNotFound, errors.Errorf("no such route"))
+	}
+}
+
+// handleEvents upgrades the request to a chunked application/x-ndjson stream:
+// a full snapshot right away, then an update (Full false, listing every
+// instance rather than only the changed ones) every time the registry
+// changes, plus a full snapshot every fullSnapshotInterval so a client that
+// missed an update can resync.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+
+	ch := s.state.bus.subscribe()
+	defer s.state.bus.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	_ = enc.Encode(client.Event{Full: true, Instances: s.state.snapshot()})
+	flusher.Flush()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := enc.Encode(ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(client.Error{Code: http.StatusText(status), Message: err.Error()})
+}
@@ -0,0 +1,359 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/components/playground/instance"
+	"github.com/pingcap/tiup/pkg/playground/client"
+)
+
+// activeFault is a fault currently applied to an instance, keyed in
+// instanceRecord.faults by faultKey so a later unpartition/expiry can find
+// and tear down the right rules.
+type activeFault struct {
+	spec  client.FaultSpec
+	ports []int       // peer ports blocked/shaped at apply time, captured so teardown doesn't need to re-resolve --target
+	timer *time.Timer // nil if the fault has no --duration and must be removed explicitly
+}
+
+// description renders a fault for display, e.g. in `tiup playground display`.
+func (f *activeFault) description() string {
+	switch f.spec.Type {
+	case faultTypeDelay:
+		desc := "delay:" + time.Duration(f.spec.Latency).String()
+		if f.spec.Target != "" {
+			desc += " to:" + f.spec.Target
+		}
+		return desc
+	case faultTypeLoss:
+		desc := "loss:" + f.spec.Loss
+		if f.spec.Target != "" {
+			desc += " to:" + f.spec.Target
+		}
+		return desc
+	default:
+		return f.spec.Type + ":" + f.spec.Target
+	}
+}
+
+// faultKey groups partition and unpartition under the same key, so that
+// injecting unpartition finds and clears the partition it's undoing.
+func faultKey(spec client.FaultSpec) string {
+	switch spec.Type {
+	case faultTypePartition, faultTypeUnpartition:
+		return faultTypePartition + "|" + spec.Target
+	default:
+		return spec.Type
+	}
+}
+
+// instanceRecord is the server's bookkeeping for one running instance.
+type instanceRecord struct {
+	pid       int
+	component string
+	port      int
+	process   *processHandle
+	startedAt time.Time
+
+	// retained so restart can relaunch the same binary.
+	binPath    string
+	configPath string
+	host       string
+
+	// faults currently applied to this instance, keyed by faultKey.
+	faults map[string]*activeFault
+}
+
+// state is the in-memory registry of every instance the playground server
+// knows about, guarded by mu so concurrent HTTP handlers can touch it safely.
+type state struct {
+	mu        sync.Mutex
+	instances map[int]*instanceRecord
+	nextPort  int
+	bus       *eventBus
+}
+
+func newState() *state {
+	return &state{
+		instances: make(map[int]*instanceRecord),
+		nextPort:  basePort,
+		bus:       newEventBus(),
+	}
+}
+
+// publishDiffLocked notifies watchers of the registry's current state. It is
+// called after every mutation (scale-out/in, restart, fault) so a watcher
+// never has to guess what changed.
+func (s *state) publishDiffLocked() {
+	s.bus.publish(client.Event{Instances: s.snapshotLocked()})
+}
+
+func (s *state) scaleOut(specs []client.InstanceSpec) ([]client.Instance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var added []client.Instance
+	for _, spec := range specs {
+		for i := 0; i < spec.Num; i++ {
+			cfg := instance.Config{Host: spec.Host, ConfigPath: spec.ConfigPath, BinPath: spec.BinPath, Num: 1}
+
+			port := s.nextPort
+			s.nextPort++
+
+			proc, err := launchInstance(cfg, port)
+			if err != nil {
+				return nil, errors.Annotatef(err, "launch %s instance", spec.Component)
+			}
+
+			rec := &instanceRecord{
+				pid:        proc.Pid(),
+				component:  spec.Component,
+				port:       port,
+				process:    proc,
+				startedAt:  time.Now(),
+				binPath:    spec.BinPath,
+				configPath: spec.ConfigPath,
+				host:       spec.Host,
+				faults:     make(map[string]*activeFault),
+			}
+			s.instances[rec.pid] = rec
+			added = append(added, rec.toClient())
+		}
+	}
+
+	s.publishDiffLocked()
+	return added, nil
+}
+
+func (s *state) scaleIn(pid int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.instances[pid]
+	if !ok {
+		return errors.Errorf("no such instance: %d", pid)
+	}
+
+	for key := range rec.faults {
+		s.clearFaultLocked(rec, key)
+	}
+
+	rec.process.Kill()
+	delete(s.instances, pid)
+	s.publishDiffLocked()
+	return nil
+}
+
+func (s *state) restart(pid int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.instances[pid]
+	if !ok {
+		return errors.Errorf("no such instance: %d", pid)
+	}
+
+	rec.process.Kill()
+
+	cfg := instance.Config{Host: rec.host, ConfigPath: rec.configPath, BinPath: rec.binPath, Num: 1}
+	proc, err := launchInstance(cfg, rec.port)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	delete(s.instances, pid)
+	rec.process = proc
+	rec.pid = proc.Pid()
+	rec.startedAt = time.Now()
+	s.instances[rec.pid] = rec
+	s.publishDiffLocked()
+	return nil
+}
+
+// injectFault applies fault to the instance identified by pid, or, for
+// faultTypeUnpartition, removes a previously applied partition.
+func (s *state) injectFault(pid int, fault client.FaultSpec) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.instances[pid]
+	if !ok {
+		return errors.Errorf("no such instance: %d", pid)
+	}
+
+	if fault.Type == faultTypeUnpartition {
+		s.clearFaultLocked(rec, faultKey(fault))
+		s.publishDiffLocked()
+		return nil
+	}
+
+	key := faultKey(fault)
+	s.clearFaultLocked(rec, key)
+
+	var ports []int
+	switch fault.Type {
+	case faultTypePartition:
+		targets, err := s.resolveTargetPortsLocked(rec, fault.Target)
+		if err != nil {
+			return err
+		}
+		for _, port := range targets {
+			if err := applyPartition(rec.port, port); err != nil {
+				return errors.AddStack(err)
+			}
+		}
+		ports = targets
+	case faultTypeDelay:
+		// --target is optional here ("empty means all traffic"); when given,
+		// scope the delay to that peer instead of the instance's whole port.
+		targets, err := s.resolveOptionalTargetPortsLocked(rec, fault.Target)
+		if err != nil {
+			return err
+		}
+		if err := applyDelay(rec.port, targets, time.Duration(fault.Latency), time.Duration(fault.Jitter)); err != nil {
+			return errors.AddStack(err)
+		}
+		ports = targets
+	case faultTypeLoss:
+		targets, err := s.resolveOptionalTargetPortsLocked(rec, fault.Target)
+		if err != nil {
+			return err
+		}
+		if err := applyLoss(rec.port, targets, fault.Loss); err != nil {
+			return errors.AddStack(err)
+		}
+		ports = targets
+	default:
+		return errors.Errorf("unknown fault type %q", fault.Type)
+	}
+
+	s.armFaultLocked(rec, key, fault, ports)
+	s.publishDiffLocked()
+	return nil
+}
+
+// resolveTargetPortsLocked turns a fault's --target (a peer pid or component
+// name) into the loopback ports applyPartition needs to block.
+func (s *state) resolveTargetPortsLocked(rec *instanceRecord, target string) ([]int, error) {
+	if pid, err := strconv.Atoi(target); err == nil {
+		peer, ok := s.instances[pid]
+		if !ok {
+			return nil, errors.Errorf("no such instance: %d", pid)
+		}
+		return []int{peer.port}, nil
+	}
+
+	var ports []int
+	for _, other := range s.instances {
+		if other.pid != rec.pid && other.component == target {
+			ports = append(ports, other.port)
+		}
+	}
+	if len(ports) == 0 {
+		return nil, errors.Errorf("no instance matches target %q", target)
+	}
+	return ports, nil
+}
+
+// resolveOptionalTargetPortsLocked is resolveTargetPortsLocked for faults
+// where --target may be left empty (delay/loss): an empty target resolves
+// to no ports rather than an error, matching the documented "empty means
+// all traffic" behavior.
+func (s *state) resolveOptionalTargetPortsLocked(rec *instanceRecord, target string) ([]int, error) {
+	if target == "" {
+		return nil, nil
+	}
+	return s.resolveTargetPortsLocked(rec, target)
+}
+
+// armFaultLocked records fault as active on rec, scheduling automatic
+// removal if fault.Duration is set. ports is the set of peer ports the fault
+// was scoped to at apply time (empty for an instance-wide delay/loss),
+// captured here rather than re-derived at teardown so a later scale-out
+// can't hand those ports to a new instance and have it inherit a stale rule.
+func (s *state) armFaultLocked(rec *instanceRecord, key string, fault client.FaultSpec, ports []int) {
+	af := &activeFault{spec: fault, ports: ports}
+	if d := time.Duration(fault.Duration); d > 0 {
+		af.timer = time.AfterFunc(d, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.clearFaultLocked(rec, key)
+			s.publishDiffLocked()
+		})
+	}
+	rec.faults[key] = af
+}
+
+// clearFaultLocked tears down the rules for the fault stored under key on
+// rec, if any. It is a no-op if no such fault is active, so callers (scale-in
+// cleanup, unpartition, expiry, re-arming) can call it unconditionally.
+func (s *state) clearFaultLocked(rec *instanceRecord, key string) {
+	af, ok := rec.faults[key]
+	if !ok {
+		return
+	}
+	if af.timer != nil {
+		af.timer.Stop()
+	}
+	delete(rec.faults, key)
+
+	switch af.spec.Type {
+	case faultTypePartition:
+		for _, port := range af.ports {
+			_ = removePartition(rec.port, port)
+		}
+	case faultTypeDelay, faultTypeLoss:
+		_ = removeNetem(rec.port)
+	}
+}
+
+func (rec *instanceRecord) toClient() client.Instance {
+	health := "down"
+	if rec.process.Alive() {
+		health = "up"
+	}
+
+	var faults []string
+	for _, af := range rec.faults {
+		faults = append(faults, af.description())
+	}
+
+	return client.Instance{
+		PID:       rec.pid,
+		Component: rec.component,
+		Role:      rec.component,
+		Uptime:    time.Since(rec.startedAt).Round(time.Second).String(),
+		Health:    health,
+		Faults:    faults,
+	}
+}
+
+func (s *state) snapshot() []client.Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked()
+}
+
+func (s *state) snapshotLocked() []client.Instance {
+	instances := make([]client.Instance, 0, len(s.instances))
+	for _, rec := range s.instances {
+		instances = append(instances, rec.toClient())
+	}
+	return instances
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/playground/client"
+	"github.com/spf13/cobra"
+)
+
+func newWatch() *cobra.Command {
+	var output string
+	var sink string
+
+	cmd := &cobra.Command{
+		Use:     "watch",
+		Short:   "Continuously stream the state of the playground cluster",
+		Example: "tiup playground watch --output json --sink http://localhost:9090/ingest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return watch(output, sink)
+		},
+		Hidden: false,
+	}
+
+	cmd.Flags().StringVar(&output, "output", "table", "output format: table or json")
+	cmd.Flags().StringVar(&sink, "sink", "", "forward every snapshot as a JSON POST to this URL")
+
+	return cmd
+}
+
+func watch(output, sink string) error {
+	pc, err := playgroundClient()
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	ctx := context.Background()
+	events, errs, err := pc.Watch(ctx)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	for ev := range events {
+		if sink != "" {
+			if err := forwardEvent(sink, ev); err != nil {
+				fmt.Fprintf(os.Stderr, "forward snapshot to sink: %v\n", err)
+			}
+		}
+
+		if output == "json" {
+			printInstancesJSON(ev.Instances)
+		} else {
+			printInstancesTable(ev.Instances)
+		}
+	}
+
+	if err := <-errs; err != nil {
+		return errors.AddStack(err)
+	}
+	return nil
+}
+
+func forwardEvent(sink string, ev client.Event) error {
+	data, err := json.Marshal(&ev)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	resp, err := http.Post(sink, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	return resp.Body.Close()
+}
+
+func printInstancesJSON(instances []client.Instance) {
+	enc := json.NewEncoder(os.Stdout)
+	_ = enc.Encode(instances)
+}
+
+// printInstancesTable redraws the terminal with a `top`-like table,
+// colorizing the health column so a broken instance stands out.
+func printInstancesTable(instances []client.Instance) {
+	fmt.Print("\x1b[2J\x1b[H")
+	fmt.Printf("%-8s %-10s %-8s %-10s %-10s %s\n", "PID", "COMPONENT", "ROLE", "UPTIME", "HEALTH", "FAULTS")
+	for _, inst := range instances {
+		fmt.Printf("%-8d %-10s %-8s %-10s %-10s %s\n",
+			inst.PID, inst.Component, inst.Role, inst.Uptime, colorizeHealth(inst.Health), strings.Join(inst.Faults, ","))
+	}
+}
+
+func colorizeHealth(health string) string {
+	switch health {
+	case "up":
+		return "\x1b[32m" + health + "\x1b[0m" // green
+	case "down":
+		return "\x1b[31m" + health + "\x1b[0m" // red
+	default:
+		return "\x1b[33m" + health + "\x1b[0m" // yellow
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client implements a Go client for the `tiup playground` HTTP API
+// (see components/playground/api/openapi.yaml for the wire format), so that
+// tests, dashboards and other tooling can drive a running playground without
+// hand-marshaling requests.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+// APIVersion is the path prefix every endpoint of the playground API is served under.
+const APIVersion = "/api/v1"
+
+// Error is the structured error envelope returned by the playground API for
+// any non-2xx response.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// InstanceSpec describes a batch of instances to scale out, one per component.
+type InstanceSpec struct {
+	Component  string `json:"component"`
+	Num        int    `json:"num"`
+	Host       string `json:"host,omitempty"`
+	ConfigPath string `json:"config_path,omitempty"`
+	BinPath    string `json:"bin_path,omitempty"`
+}
+
+// Instance is one running playground instance, as returned by GET /instances
+// and streamed by GET /events.
+type Instance struct {
+	PID       int      `json:"pid"`
+	Component string   `json:"component"`
+	Role      string   `json:"role"`
+	Uptime    string   `json:"uptime"`
+	Health    string   `json:"health"`           // one of "up", "down", "unknown"
+	Faults    []string `json:"faults,omitempty"` // active partition/delay/loss faults on this instance
+}
+
+// Duration marshals as a Go duration string (e.g. "30s") instead of the
+// integer nanoseconds time.Duration would otherwise produce, matching the
+// wire format documented in components/playground/api/openapi.yaml.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// FaultSpec describes a network fault to inject against, or remove from, an instance.
+type FaultSpec struct {
+	Type     string   `json:"type"` // partition, unpartition, delay or loss
+	Target   string   `json:"target,omitempty"`
+	Duration Duration `json:"duration,omitempty"`
+	Latency  Duration `json:"latency,omitempty"`
+	Jitter   Duration `json:"jitter,omitempty"`
+	Loss     string   `json:"loss,omitempty"`
+}
+
+// Event is one message on the GET /events stream: either the periodic full
+// snapshot (Full true), or an update emitted when a scale/restart/fault event
+// fires (Full false). Both carry every instance, not only the changed ones.
+type Event struct {
+	Full      bool       `json:"full"`
+	Instances []Instance `json:"instances"`
+}
+
+// Client talks to a running `tiup playground`'s HTTP API.
+type Client interface {
+	// ScaleOut starts the instances described by specs and returns them.
+	ScaleOut(ctx context.Context, specs []InstanceSpec) ([]Instance, error)
+	// ScaleIn stops the instance with the given pid.
+	ScaleIn(ctx context.Context, pid int) error
+	// Restart restarts the instance with the given pid.
+	Restart(ctx context.Context, pid int) error
+	// InjectFault applies fault to the instance with the given pid.
+	InjectFault(ctx context.Context, pid int, fault FaultSpec) error
+	// Display returns the current state of every instance.
+	Display(ctx context.Context) ([]Instance, error)
+	// Watch opens a long-lived stream of Events. Both channels are closed
+	// when ctx is canceled; if the connection to the server is lost first,
+	// the decode error is sent on the error channel before it closes.
+	Watch(ctx context.Context) (<-chan Event, <-chan error, error)
+}
+
+// NewClient returns a Client talking to the playground API server at addr (host:port).
+func NewClient(addr string) Client {
+	return &httpClient{addr: addr, hc: &http.Client{}}
+}
+
+type httpClient struct {
+	addr string
+	hc   *http.Client
+}
+
+func (c *httpClient) url(path string) string {
+	return fmt.Sprintf("http://%s%s%s", c.addr, APIVersion, path)
+}
+
+func (c *httpClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.url(path), reader)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr Error
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+			return errors.Errorf("playground API request failed with status %d", resp.StatusCode)
+		}
+		return &apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	return errors.AddStack(json.NewDecoder(resp.Body).Decode(out))
+}
+
+func (c *httpClient) ScaleOut(ctx context.Context, specs []InstanceSpec) ([]Instance, error) {
+	var instances []Instance
+	if err := c.do(ctx, http.MethodPost, "/instances", specs, &instances); err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+func (c *httpClient) ScaleIn(ctx context.Context, pid int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/instances/%d", pid), nil, nil)
+}
+
+func (c *httpClient) Restart(ctx context.Context, pid int) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/instances/%d/restart", pid), nil, nil)
+}
+
+func (c *httpClient) InjectFault(ctx context.Context, pid int, fault FaultSpec) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/instances/%d/faults", pid), fault, nil)
+}
+
+func (c *httpClient) Display(ctx context.Context) ([]Instance, error) {
+	var instances []Instance
+	if err := c.do(ctx, http.MethodGet, "/instances", nil, &instances); err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+func (c *httpClient) Watch(ctx context.Context) (<-chan Event, <-chan error, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url("/events"), nil)
+	if err != nil {
+		return nil, nil, errors.AddStack(err)
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, nil, errors.AddStack(err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, nil, errors.Errorf("playground API request failed with status %d", resp.StatusCode)
+	}
+
+	events := make(chan Event)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(events)
+		defer close(errs)
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var ev Event
+			if err := dec.Decode(&ev); err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					errs <- errors.AddStack(err)
+				}
+				return
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errs, nil
+}
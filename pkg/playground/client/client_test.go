@@ -0,0 +1,110 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScaleOutRequestURL(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		_ = json.NewEncoder(w).Encode([]Instance{{PID: 1}})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Listener.Addr().String())
+	if _, err := c.ScaleOut(context.Background(), []InstanceSpec{{Component: "tikv", Num: 1}}); err != nil {
+		t.Fatalf("ScaleOut: %v", err)
+	}
+	if gotMethod != http.MethodPost || gotPath != "/api/v1/instances" {
+		t.Fatalf("got %s %s, want POST /api/v1/instances", gotMethod, gotPath)
+	}
+}
+
+func TestScaleInAndRestartRequestURL(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Listener.Addr().String())
+
+	if err := c.ScaleIn(context.Background(), 1234); err != nil {
+		t.Fatalf("ScaleIn: %v", err)
+	}
+	if gotMethod != http.MethodDelete || gotPath != "/api/v1/instances/1234" {
+		t.Fatalf("got %s %s, want DELETE /api/v1/instances/1234", gotMethod, gotPath)
+	}
+
+	if err := c.Restart(context.Background(), 1234); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if gotMethod != http.MethodPost || gotPath != "/api/v1/instances/1234/restart" {
+		t.Fatalf("got %s %s, want POST /api/v1/instances/1234/restart", gotMethod, gotPath)
+	}
+}
+
+func TestDurationMarshalUnmarshalRoundTrip(t *testing.T) {
+	want := Duration(50 * time.Millisecond)
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `"50ms"` {
+		t.Fatalf("Marshal(50ms) = %s, want %q", data, `"50ms"`)
+	}
+
+	var got Duration
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got != want {
+		t.Fatalf("round trip = %v, want %v", got, want)
+	}
+}
+
+func TestErrorEnvelopeDecode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Code: "not_found", Message: "no such instance: 1234"})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Listener.Addr().String())
+	err := c.ScaleIn(context.Background(), 1234)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	apiErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("got error of type %T, want *Error", err)
+	}
+	if apiErr.Code != "not_found" || apiErr.Message != "no such instance: 1234" {
+		t.Fatalf("got %+v, want {Code: not_found, Message: no such instance: 1234}", apiErr)
+	}
+	if apiErr.Error() != "not_found: no such instance: 1234" {
+		t.Fatalf("Error() = %q", apiErr.Error())
+	}
+}